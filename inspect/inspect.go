@@ -0,0 +1,307 @@
+// Package inspect opens downloaded WinWorld archives (ISO, 7z, ZIP, and
+// friends) and records what's inside them: the top-level file tree, whether
+// an installer or autorun is present, which OS family the layout suggests,
+// and any shortcuts that point at a separately hosted installer. Results are
+// emitted as a Record per File, since the variable-length file tree doesn't
+// fit the flat results CSV.
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/kdomanski/iso9660"
+	"github.com/mholt/archiver/v4"
+	"howett.net/plist"
+)
+
+// Shortcut is a linked-out installer found inside a .url/.desktop/.webloc
+// entry.
+type Shortcut struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+}
+
+// Record is the archive-introspection result for one File, keyed by its
+// GUID in results_contents.jsonl.
+type Record struct {
+	GUID                  string     `json:"guid"`
+	ArchiveType           string     `json:"archive_type"`
+	TopLevelEntries       []string   `json:"top_level_entries"`
+	TotalUncompressedSize int64      `json:"total_uncompressed_size"`
+	HasSetupExe           bool       `json:"has_setup_exe"`
+	HasAutorunInf         bool       `json:"has_autorun_inf"`
+	DetectedOS            string     `json:"detected_os,omitempty"`
+	Shortcuts             []Shortcut `json:"shortcuts,omitempty"`
+	SkippedReason         string     `json:"skipped_reason,omitempty"`
+}
+
+// osSignatures maps a top-level directory name to the OS family it implies.
+var osSignatures = map[string]string{
+	"i386":  "winnt",
+	"win95": "win9x",
+	"win98": "win9x",
+}
+
+// entry is the archive-format-agnostic view of one file Inspect works with.
+type entry struct {
+	name             string // full path within the archive
+	isDir            bool
+	uncompressedSize int64
+	open             func() (io.ReadCloser, error)
+}
+
+const maxShortcutSize = 64 << 10 // shortcuts are tiny INI/plist files
+
+// Inspect opens the archive at filePath (whose original download GUID was guid),
+// dispatches on its extension, and returns a Record describing its
+// contents. Archives larger than maxSize, or of an unsupported/corrupt
+// format, produce a Record with SkippedReason set rather than an error.
+func Inspect(guid, filePath string, maxSize int64) (*Record, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{GUID: guid}
+
+	if info.Size() > maxSize {
+		record.SkippedReason = fmt.Sprintf("archive size %d exceeds max-archive-size %d", info.Size(), maxSize)
+		return record, nil
+	}
+
+	ext := strings.ToLower(path.Ext(filePath))
+
+	var entries []entry
+	switch ext {
+	case ".iso":
+		record.ArchiveType = "iso9660"
+		entries, err = readISO9660(filePath)
+	case ".7z":
+		record.ArchiveType = "7z"
+		entries, err = readSevenZip(filePath)
+	default:
+		record.ArchiveType = strings.TrimPrefix(ext, ".")
+		entries, err = readGeneric(filePath)
+	}
+
+	if err != nil {
+		record.SkippedReason = err.Error()
+		return record, nil
+	}
+
+	populateRecord(record, entries)
+	return record, nil
+}
+
+func populateRecord(record *Record, entries []entry) {
+	seenTopLevel := make(map[string]bool)
+
+	for _, e := range entries {
+		if e.isDir {
+			continue
+		}
+
+		record.TotalUncompressedSize += e.uncompressedSize
+
+		top := strings.SplitN(strings.TrimPrefix(e.name, "/"), "/", 2)[0]
+		if !seenTopLevel[top] {
+			seenTopLevel[top] = true
+			record.TopLevelEntries = append(record.TopLevelEntries, top)
+		}
+
+		base := strings.ToLower(path.Base(e.name))
+		switch base {
+		case "setup.exe":
+			record.HasSetupExe = true
+		case "autorun.inf":
+			record.HasAutorunInf = true
+		}
+
+		if os, ok := osSignatures[strings.ToLower(top)]; ok && record.DetectedOS == "" {
+			record.DetectedOS = os
+		}
+
+		if shortcut := parseShortcut(e); shortcut != nil {
+			record.Shortcuts = append(record.Shortcuts, *shortcut)
+		}
+	}
+}
+
+func parseShortcut(e entry) *Shortcut {
+	ext := strings.ToLower(path.Ext(e.name))
+	if ext != ".url" && ext != ".desktop" && ext != ".webloc" {
+		return nil
+	}
+	if e.uncompressedSize > maxShortcutSize {
+		return nil
+	}
+
+	r, err := e.open()
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxShortcutSize))
+	if err != nil {
+		return nil
+	}
+
+	var target string
+	switch ext {
+	case ".url", ".desktop":
+		target = parseINITarget(data)
+	case ".webloc":
+		target = parseWeblocTarget(data)
+	}
+
+	if target == "" {
+		return nil
+	}
+
+	return &Shortcut{Name: path.Base(e.name), Target: target}
+}
+
+// parseINITarget pulls the URL= (or Exec=) value out of a .url/.desktop
+// INI-style shortcut. These files are small and flat enough that a full INI
+// parser would be overkill.
+func parseINITarget(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "url", "exec":
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func parseWeblocTarget(data []byte) string {
+	var doc struct {
+		URL string `plist:"URL"`
+	}
+	if _, err := plist.Unmarshal(data, &doc); err != nil {
+		return ""
+	}
+	return doc.URL
+}
+
+func readISO9660(filePath string) ([]entry, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := iso9660.OpenImage(f)
+	if err != nil {
+		return nil, fmt.Errorf("iso9660: %w", err)
+	}
+
+	root, err := img.RootDir()
+	if err != nil {
+		return nil, fmt.Errorf("iso9660: %w", err)
+	}
+
+	var entries []entry
+	var walk func(dir *iso9660.File, prefix string) error
+	walk = func(dir *iso9660.File, prefix string) error {
+		children, err := dir.GetChildren()
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			name := prefix + child.Name()
+			if child.IsDir() {
+				entries = append(entries, entry{name: name, isDir: true})
+				if err := walk(child, name+"/"); err != nil {
+					return err
+				}
+				continue
+			}
+
+			reader := child.Reader()
+			entries = append(entries, entry{
+				name:             name,
+				uncompressedSize: child.Size(),
+				open:             func() (io.ReadCloser, error) { return io.NopCloser(reader), nil },
+			})
+		}
+		return nil
+	}
+
+	if err := walk(root, "/"); err != nil {
+		return nil, fmt.Errorf("iso9660: %w", err)
+	}
+
+	return entries, nil
+}
+
+func readSevenZip(filePath string) ([]entry, error) {
+	r, err := sevenzip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("7z: %w", err)
+	}
+	defer r.Close()
+
+	entries := make([]entry, 0, len(r.File))
+	for _, file := range r.File {
+		file := file
+		entries = append(entries, entry{
+			name:             file.Name,
+			isDir:            file.FileInfo().IsDir(),
+			uncompressedSize: int64(file.UncompressedSize),
+			open:             func() (io.ReadCloser, error) { return file.Open() },
+		})
+	}
+
+	return entries, nil
+}
+
+// readGeneric handles ZIP and anything else archiver/v4 recognizes by
+// format signature (tar, tar.gz, rar, ...).
+func readGeneric(filePath string) ([]entry, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	format, stream, err := archiver.Identify(filePath, f)
+	if err != nil {
+		return nil, fmt.Errorf("archiver: %w", err)
+	}
+
+	extractor, ok := format.(archiver.Extractor)
+	if !ok {
+		return nil, fmt.Errorf("archiver: %s format does not support extraction", format.Name())
+	}
+
+	var entries []entry
+	err = extractor.Extract(context.Background(), stream, nil, func(_ context.Context, file archiver.File) error {
+		entries = append(entries, entry{
+			name:             file.NameInArchive,
+			isDir:            file.IsDir(),
+			uncompressedSize: file.Size(),
+			open:             func() (io.ReadCloser, error) { return file.Open() },
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archiver: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,292 @@
+// Package ipfs resolves the IPFS links scraped from WinWorld download pages,
+// streams their content through a gateway to verify it against the hash
+// WinWorld publishes, and optionally pins verified content to a local Kubo
+// node so a mirror operator can archive it.
+package ipfs
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of verifying a File's IPFS content against its
+// scraped hash.
+type Status string
+
+const (
+	StatusVerified     Status = "Verified"
+	StatusHashMismatch Status = "HashMismatch"
+	StatusUnreachable  Status = "Unreachable"
+)
+
+// DefaultGateways is tried in order until one serves the content.
+var DefaultGateways = []string{
+	"https://ipfs.io/ipfs/",
+	"https://dweb.link/ipfs/",
+	"https://cf-ipfs.com/ipfs/",
+	"http://127.0.0.1:8080/ipfs/",
+}
+
+const defaultMaxSize = 4 << 30 // 4 GiB, generously above the largest WinWorld ISO
+
+// Verifier streams IPFS content through a set of gateways and checks it
+// against an expected hash, with an optional pin-to-local-node step.
+type Verifier struct {
+	gateways []string
+	client   *http.Client
+	maxSize  int64
+
+	pinAPI string
+	pinSem chan struct{}
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithGateways overrides DefaultGateways.
+func WithGateways(gateways []string) Option {
+	return func(v *Verifier) { v.gateways = gateways }
+}
+
+// WithMaxSize caps how many bytes of content are streamed per file before
+// verification is aborted.
+func WithMaxSize(maxSize int64) Option {
+	return func(v *Verifier) { v.maxSize = maxSize }
+}
+
+// WithPin enables -pin mode against a local Kubo node's HTTP API (e.g.
+// "http://127.0.0.1:5001"), with pinConcurrency controlling how many pins
+// may be in flight at once, independent of the scrape worker pool.
+func WithPin(pinAPI string, pinConcurrency int) Option {
+	return func(v *Verifier) {
+		v.pinAPI = pinAPI
+		v.pinSem = make(chan struct{}, pinConcurrency)
+	}
+}
+
+// NewVerifier builds a Verifier with DefaultGateways and a 4 GiB size cap,
+// overridden by any Options passed in.
+func NewVerifier(opts ...Option) *Verifier {
+	v := &Verifier{
+		gateways: DefaultGateways,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		maxSize:  defaultMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// ExtractCID pulls the CID out of a scraped IPFS link, which may be an
+// ipfs:// URI or a gateway URL of the form ".../ipfs/<cid>[/...]".
+func ExtractCID(link string) (string, error) {
+	link = strings.TrimSpace(link)
+
+	rest, ok := strings.CutPrefix(link, "ipfs://")
+	if ok {
+		rest = strings.TrimLeft(rest, "/")
+	} else {
+		idx := strings.Index(link, "/ipfs/")
+		if idx == -1 {
+			return "", fmt.Errorf("ipfs: no /ipfs/ segment in link %q", link)
+		}
+		rest = link[idx+len("/ipfs/"):]
+	}
+
+	rest = strings.SplitN(rest, "/", 2)[0]
+	rest = strings.SplitN(rest, "?", 2)[0]
+	if rest == "" {
+		return "", fmt.Errorf("ipfs: empty cid in link %q", link)
+	}
+
+	return rest, nil
+}
+
+// Verify streams the content addressed by link through the configured
+// gateways (stopping at the first that serves it) and compares its MD5 and
+// SHA1 against expectedHash, which WinWorld may publish as either. The
+// content is never buffered in memory; it is hashed as it streams and
+// discarded.
+func (v *Verifier) Verify(link, expectedHash string) Status {
+	cid, err := ExtractCID(link)
+	if err != nil {
+		log.Println(err)
+		return StatusUnreachable
+	}
+
+	expectedHash = strings.ToLower(strings.TrimSpace(expectedHash))
+
+	md5sum, sha1sum, err := v.hashViaGateways(cid)
+	if err != nil {
+		log.Printf("ipfs: %s unreachable on all gateways: %v", cid, err)
+		return StatusUnreachable
+	}
+
+	if expectedHash == "" || md5sum == expectedHash || sha1sum == expectedHash {
+		return StatusVerified
+	}
+
+	return StatusHashMismatch
+}
+
+func (v *Verifier) hashViaGateways(cid string) (md5sum, sha1sum string, err error) {
+	var lastErr error
+
+	for _, gateway := range v.gateways {
+		md5sum, sha1sum, lastErr = v.hashViaGateway(gateway, cid)
+		if lastErr == nil {
+			return md5sum, sha1sum, nil
+		}
+		log.Printf("ipfs: gateway %s failed for %s: %v", gateway, cid, lastErr)
+	}
+
+	return "", "", lastErr
+}
+
+// FetchToFile streams the content addressed by link to destPath, trying
+// each gateway in turn until one serves it, enforcing maxSize rather than
+// the Verifier's own configured max size, since a caller fetching for a
+// different purpose (e.g. archive introspection) may allow a larger file
+// than Verify does. Unlike Verify, the content is written to disk rather
+// than discarded, for callers that need random access to the bytes
+// afterward.
+func (v *Verifier) FetchToFile(link, destPath string, maxSize int64) error {
+	cid, err := ExtractCID(link)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, gateway := range v.gateways {
+		if lastErr = v.fetchViaGateway(gateway, cid, destPath, maxSize); lastErr == nil {
+			return nil
+		}
+		log.Printf("ipfs: gateway %s failed for %s: %v", gateway, cid, lastErr)
+	}
+
+	return lastErr
+}
+
+func (v *Verifier) fetchViaGateway(gateway, cid, destPath string, maxSize int64) error {
+	res, err := v.client.Get(gateway + cid)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	n, err := io.Copy(out, io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if n > maxSize {
+		return fmt.Errorf("content exceeds max size of %d bytes", maxSize)
+	}
+
+	return nil
+}
+
+func (v *Verifier) hashViaGateway(gateway, cid string) (md5sum, sha1sum string, err error) {
+	res, err := v.client.Get(gateway + cid)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	md5h := md5.New()
+	sha1h := sha1.New()
+
+	limited := io.LimitReader(res.Body, v.maxSize+1)
+	n, err := io.Copy(io.MultiWriter(md5h, sha1h), limited)
+	if err != nil {
+		return "", "", err
+	}
+	if n > v.maxSize {
+		return "", "", fmt.Errorf("content exceeds max size of %d bytes", v.maxSize)
+	}
+
+	return hashHex(md5h), hashHex(sha1h), nil
+}
+
+func hashHex(h hash.Hash) string {
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Pin asks the configured local Kubo node to pin cid, so its content is
+// retained on the mirror operator's own node. It is gated by its own
+// concurrency limit, separate from the scrape worker pool, since pinning
+// can take far longer than a single HTTP fetch.
+func (v *Verifier) Pin(cid string) error {
+	if v.pinAPI == "" {
+		return fmt.Errorf("ipfs: pin requested but no pin API configured")
+	}
+
+	v.pinSem <- struct{}{}
+	defer func() { <-v.pinSem }()
+
+	pinURL := strings.TrimSuffix(v.pinAPI, "/") + "/api/v0/pin/add?arg=" + cid
+
+	res, err := v.client.Post(pinURL, "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	log.Printf("ipfs: pinned %s", cid)
+	return nil
+}
+
+// ParseSize parses a human size flag value like "4GiB" or a plain byte
+// count into bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	multiplier := int64(1)
+
+	switch {
+	case strings.HasSuffix(s, "GiB"):
+		multiplier = 1 << 30
+		s = strings.TrimSuffix(s, "GiB")
+	case strings.HasSuffix(s, "MiB"):
+		multiplier = 1 << 20
+		s = strings.TrimSuffix(s, "MiB")
+	case strings.HasSuffix(s, "KiB"):
+		multiplier = 1 << 10
+		s = strings.TrimSuffix(s, "KiB")
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return n * multiplier, nil
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -9,21 +12,63 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	gq "github.com/PuerkitoBio/goquery"
+	"golang.org/x/time/rate"
+
+	"github.com/Neo-Desktop/winworldpc-ipfs-scraper/inspect"
+	"github.com/Neo-Desktop/winworldpc-ipfs-scraper/ipfs"
+	"github.com/Neo-Desktop/winworldpc-ipfs-scraper/state"
+	"github.com/Neo-Desktop/winworldpc-ipfs-scraper/useragent"
 )
 
 const (
 	URL                    = "https://winworldpc.com"
 	csvFileName            = "results.csv"
 	csvFullResultsFileName = "results_full.csv"
+	contentsFileName       = "results_contents.jsonl"
 	logFileName            = "output.log"
+	uaCacheFileName        = "useragent_cache.json"
 	UserAgent              = "Mozilla/5.0 (compatible; IPFS.ScraperBot/v1.1; +https://github.com/Neo-Desktop/winworldpc-ipfs-scraper)"
 )
 
+var (
+	workers   = flag.Int("workers", 8, "number of concurrent crawl workers")
+	hostQPS   = flag.Float64("qps", 2, "requests per second allowed per host")
+	hostBurst = flag.Int("burst", 1, "burst size allowed per host")
+	uaMode    = flag.String("user-agent-mode", "static", "User-Agent strategy: static, rotate, or sticky")
+
+	ipfsGateways    = flag.String("ipfs-gateways", strings.Join(ipfs.DefaultGateways, ","), "comma-separated IPFS gateways to try, in order")
+	ipfsMaxFileSize = flag.String("ipfs-max-size", "4GiB", "largest file to stream through a gateway while verifying (e.g. 4GiB)")
+	pin             = flag.Bool("pin", false, "pin every verified file to a local Kubo node")
+	pinAPI          = flag.String("pin-api", "http://127.0.0.1:5001", "local Kubo node API address used by -pin")
+	pinConcurrency  = flag.Int("pin-concurrency", 2, "max concurrent pin requests, independent of -workers")
+
+	inspectArchives    = flag.Bool("inspect", false, "fetch and open each downloaded archive to record its contents")
+	inspectMaxFileSize = flag.String("inspect-max-size", "8GiB", "largest archive to download and inspect (e.g. 8GiB)")
+
+	crawlMode   = flag.String("mode", "full", "crawl mode: full (ignore prior state), incremental (skip pages unchanged within -since), or resume (conditionally re-validate everything already in the state store)")
+	since       = flag.Duration("since", 24*time.Hour, "in -mode=incremental, only attempt conditional validation for pages fetched within this long ago; older pages are always fully refetched")
+	stateDBPath = flag.String("state-db", "state.db", "path to the persistent crawl state store")
+)
+
+var (
+	uaProvider     *useragent.Provider
+	ipfsVerifier   *ipfs.Verifier
+	contentsWriter *jsonlWriter
+	stateStore     *state.Store
+
+	// inspectMaxSize is *inspectMaxFileSize parsed once at startup, next to
+	// ipfsVerifier's own maxSize, so a malformed -inspect-max-size fails
+	// fast instead of surfacing deep inside a crawl worker.
+	inspectMaxSize int64
+)
+
 type File struct {
 	Name         string
 	Version      string
@@ -33,6 +78,7 @@ type File struct {
 	Hash         string
 	Architecture string
 	IPFSLink     string
+	IPFSStatus   string
 	MirrorLinks  []string
 }
 
@@ -46,6 +92,7 @@ func (f File) MarshalCSV() []string {
 		f.Hash,
 		f.Architecture,
 		f.IPFSLink,
+		f.IPFSStatus,
 	}
 
 	out = append(out, f.MirrorLinks...)
@@ -56,7 +103,283 @@ type Article struct {
 	Title    string
 	Version  string
 	WWPCLink string
-	Files    []File
+
+	mu    sync.Mutex
+	Files []File
+}
+
+func (a *Article) addFile(file File) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Files = append(a.Files, file)
+}
+
+// jobKind identifies which stage of the crawl a job belongs to.
+type jobKind int
+
+const (
+	jobSearchPage jobKind = iota
+	jobArticle
+	jobDownload
+)
+
+// job is a single unit of crawl work pushed onto the shared jobs channel.
+// Only the fields relevant to kind are populated.
+type job struct {
+	kind jobKind
+
+	page uint // jobSearchPage
+
+	article *Article // jobArticle, jobDownload
+	link    string   // jobArticle
+
+	file      File            // jobDownload
+	articleWG *sync.WaitGroup // jobDownload: signals when the parent article is complete
+}
+
+// crawler holds the shared state for a single run: the job/result channels
+// and URL dedup table. The per-host rate limiters live in hostLimiters.
+type crawler struct {
+	jobs    chan job
+	results chan *Article
+
+	jobsWG     sync.WaitGroup // outstanding jobs, used to know when to close(jobs)
+	articlesWG sync.WaitGroup // outstanding in-flight articles, used to know when to close(results)
+
+	seenMu sync.Mutex
+	seen   map[string]bool
+}
+
+func newCrawler() *crawler {
+	return &crawler{
+		jobs:    make(chan job, 1024),
+		results: make(chan *Article, 64),
+		seen:    make(map[string]bool),
+	}
+}
+
+// markSeen returns true the first time it is called for a given key, and
+// false on every subsequent call, so callers can deduplicate work across
+// the whole run.
+func (c *crawler) markSeen(key string) bool {
+	c.seenMu.Lock()
+	defer c.seenMu.Unlock()
+	if c.seen[key] {
+		return false
+	}
+	c.seen[key] = true
+	return true
+}
+
+func (c *crawler) enqueue(j job) {
+	c.jobsWG.Add(1)
+	c.jobs <- j
+}
+
+func (c *crawler) worker(id int) {
+	for j := range c.jobs {
+		switch j.kind {
+		case jobSearchPage:
+			c.processSearchPage(id, j.page)
+		case jobArticle:
+			c.processArticle(id, j.article, j.link)
+		case jobDownload:
+			c.processDownload(id, j.article, j.file, j.articleWG)
+		}
+		c.jobsWG.Done()
+	}
+}
+
+func (c *crawler) processSearchPage(workerID int, page uint) {
+	for _, a := range scrapeSearchPageLinks(workerID, page) {
+		article := a.article
+		if !c.markSeen(article.WWPCLink) {
+			continue
+		}
+		c.articlesWG.Add(1)
+		c.enqueue(job{kind: jobArticle, article: article, link: a.link})
+	}
+}
+
+type articleStub struct {
+	article *Article
+	link    string
+}
+
+func (c *crawler) processArticle(workerID int, article *Article, link string) {
+	files, unchanged := scrapeArticlePageFiles(workerID, article, link)
+	if unchanged {
+		c.articlesWG.Done()
+		restoreStoredFiles(article)
+		return
+	}
+
+	if len(files) == 0 {
+		c.persistAndPublish(article)
+		c.articlesWG.Done()
+		return
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(files))
+	go func() {
+		wg.Wait()
+		c.persistAndPublish(article)
+		c.articlesWG.Done()
+	}()
+
+	for _, file := range files {
+		c.enqueue(job{kind: jobDownload, article: article, file: file, articleWG: wg})
+	}
+}
+
+// persistAndPublish records a fully-scraped article in the state store,
+// then hands it to the results channel for the live results.csv writer.
+// results_full.csv is rendered straight from the store at the end of the
+// run, so the store is the one place that must never be skipped.
+func (c *crawler) persistAndPublish(article *Article) {
+	if err := stateStore.PutArticle(article.WWPCLink, article); err != nil {
+		log.Printf("state: failed to persist article %s: %v", article.WWPCLink, err)
+	}
+	c.results <- article
+}
+
+// restoreStoredFiles is used when an article page came back 304: the page
+// itself hasn't changed, so whatever was stored for it last run is still
+// accurate and there is no need to re-walk its downloads.
+func restoreStoredFiles(article *Article) {
+	var persisted Article
+	found, err := stateStore.GetArticle(article.WWPCLink, &persisted)
+	if err != nil || !found {
+		log.Printf("state: %s reported unchanged but has no stored state, skipping", article.WWPCLink)
+		return
+	}
+	article.Files = persisted.Files
+}
+
+func (c *crawler) processDownload(workerID int, article *Article, file File, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scraped, unchanged := scrapeDownloadPage(workerID, file, true)
+	if unchanged {
+		if prior := lookupStoredFile(article.WWPCLink, file.GUID); prior != nil {
+			scraped = *prior
+		} else {
+			log.Printf("state: %s reported unchanged but has no stored state, forcing refetch", file.GUID)
+			scraped, _ = scrapeDownloadPage(workerID, file, false)
+		}
+	}
+
+	verifyAndPin(&scraped)
+	inspectFile(scraped)
+	article.addFile(scraped)
+}
+
+// lookupStoredFile finds a previously-persisted File by GUID within the
+// article stored under articleKey, for reuse when its download page came
+// back 304.
+func lookupStoredFile(articleKey, guid string) *File {
+	var persisted Article
+	found, err := stateStore.GetArticle(articleKey, &persisted)
+	if err != nil || !found {
+		return nil
+	}
+
+	for i := range persisted.Files {
+		if persisted.Files[i].GUID == guid {
+			return &persisted.Files[i]
+		}
+	}
+	return nil
+}
+
+// verifyAndPin checks a File's IPFS content against its scraped hash and,
+// if -pin is set, asks the local Kubo node to pin it. It is a no-op for
+// files that have no IPFS link.
+func verifyAndPin(file *File) {
+	if file.IPFSLink == "" {
+		return
+	}
+
+	file.IPFSStatus = string(ipfsVerifier.Verify(file.IPFSLink, file.Hash))
+
+	if !*pin || file.IPFSStatus != string(ipfs.StatusVerified) {
+		return
+	}
+
+	cid, err := ipfs.ExtractCID(file.IPFSLink)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err := ipfsVerifier.Pin(cid); err != nil {
+		log.Printf("ipfs: failed to pin %s: %v", cid, err)
+	}
+}
+
+// inspectFile downloads a File's IPFS content to a temp file and opens it as
+// an archive to record its contents, emitting the result to
+// results_contents.jsonl. It is a no-op unless -inspect is set and the file
+// has an IPFS link, and skips cleanly (logging why) for a file that cannot
+// be fetched or an unopenable archive.
+func inspectFile(file File) {
+	if !*inspectArchives || file.IPFSLink == "" {
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "wwpc-inspect-*"+path.Ext(file.IPFSLink))
+	if err != nil {
+		log.Printf("inspect: failed to create temp file for %s: %v", file.GUID, err)
+		return
+	}
+	tmpPath := tmp.Name()
+	_ = tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := ipfsVerifier.FetchToFile(file.IPFSLink, tmpPath, inspectMaxSize); err != nil {
+		log.Printf("inspect: failed to fetch %s for inspection: %v", file.GUID, err)
+		return
+	}
+
+	record, err := inspect.Inspect(file.GUID, tmpPath, inspectMaxSize)
+	if err != nil {
+		log.Printf("inspect: failed to inspect %s: %v", file.GUID, err)
+		return
+	}
+
+	if record.SkippedReason != "" {
+		log.Printf("inspect: skipped %s: %s", file.GUID, record.SkippedReason)
+	}
+
+	contentsWriter.write(record)
+}
+
+// jsonlWriter appends one JSON object per line to a file, guarded by a
+// mutex so concurrent crawl workers never interleave writes.
+type jsonlWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	f   *os.File
+}
+
+func newJSONLWriter(filename string) (*jsonlWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonlWriter{enc: json.NewEncoder(f), f: f}, nil
+}
+
+func (w *jsonlWriter) write(v interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.enc.Encode(v)
+}
+
+func (w *jsonlWriter) close() {
+	_ = w.f.Close()
 }
 
 func scrapeSearchPageForUpperBound() uint {
@@ -67,7 +390,7 @@ func scrapeSearchPageForUpperBound() uint {
 
 	log.Printf("Fetching search paginaion upper bound")
 
-	res, err := fetch(urlA.String())
+	res, err := fetch(0, urlA.String(), false)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -95,7 +418,9 @@ func scrapeSearchPageForUpperBound() uint {
 	return uint(out)
 }
 
-func scrapeSearchPage(page uint) []Article {
+// scrapeSearchPageLinks fetches a single search results page and returns an
+// Article stub (title/version/link, no files yet) for every result listed.
+func scrapeSearchPageLinks(workerID int, page uint) []articleStub {
 	log.Printf("=============================== PAGE %2d ===============================", page)
 	urlA, err := url.Parse(URL + "/search")
 	if err != nil {
@@ -107,28 +432,35 @@ func scrapeSearchPage(page uint) []Article {
 	queryParameters.Add("page", fmt.Sprintf("%d", page))
 	urlA.RawQuery = queryParameters.Encode()
 
-	res, err := fetch(urlA.String())
+	res, err := fetch(workerID, urlA.String(), true)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		return nil
 	}
 
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		log.Printf("page %d unchanged since last crawl, skipping", page)
+		return nil
+	}
 	if res.StatusCode != http.StatusOK {
-		log.Fatalf("status code error: %d %s", res.StatusCode, res.Status)
+		log.Printf("status code error: %d %s", res.StatusCode, res.Status)
+		return nil
 	}
 
 	doc, err := gq.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		return nil
 	}
 
-	articles := make([]Article, 0)
+	stubs := make([]articleStub, 0)
 
 	doc.Find(".media>.media-body").Each(func(i int, s *gq.Selection) {
 		title := strings.TrimSpace(s.Find(".mt-0 a").First().Text())
 
 		s.Find(".nav>.nav-link>a").Each(func(j int, s1 *gq.Selection) {
-			url, ok := s1.Attr("href")
+			link, ok := s1.Attr("href")
 			if !ok {
 				log.Printf("anchor does not have a href")
 				return
@@ -136,36 +468,48 @@ func scrapeSearchPage(page uint) []Article {
 
 			version := strings.TrimSpace(s1.Text())
 
-			articles = append(articles, scrapeArticlePage(Article{
-				Title:    title,
-				Version:  version,
-				WWPCLink: url,
-			}))
+			stubs = append(stubs, articleStub{
+				article: &Article{
+					Title:    title,
+					Version:  version,
+					WWPCLink: link,
+				},
+				link: link,
+			})
 		})
 	})
 
-	return articles
+	return stubs
 }
 
-func scrapeArticlePage(article Article) Article {
-	res, err := fetch(URL + article.WWPCLink)
+// scrapeArticlePageFiles fetches an article page and returns a File stub
+// (metadata scraped from the downloads table, no IPFS/mirror links yet) for
+// every row found. unchanged is true when the page came back 304, meaning
+// the caller should reuse whatever was stored for this article last run.
+func scrapeArticlePageFiles(workerID int, article *Article, link string) (files []File, unchanged bool) {
+	res, err := fetch(workerID, URL+link, true)
 	if err != nil {
 		log.Println(err)
-		return article
+		return nil, false
 	}
 
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return nil, true
+	}
 	if res.StatusCode != http.StatusOK {
 		log.Printf("status code error: %d %s", res.StatusCode, res.Status)
-		return article
+		return nil, false
 	}
 
 	doc, err := gq.NewDocumentFromReader(res.Body)
 	if err != nil {
 		log.Println(err)
-		return article
+		return nil, false
 	}
 
+	files = make([]File, 0)
+
 	doc.Find("#downloadsTable tbody tr").Each(func(i int, tr *gq.Selection) {
 		file := File{}
 
@@ -202,29 +546,35 @@ func scrapeArticlePage(article Article) Article {
 			}
 		})
 
-		article.Files = append(article.Files, scrapeDownloadPage(file))
+		files = append(files, file)
 	})
 
-	return article
+	return files, false
 }
 
-func scrapeDownloadPage(file File) File {
-	res, err := fetch(URL + "/download/" + file.GUID)
+// scrapeDownloadPage fetches a download page for file and fills in its
+// IPFS/mirror links. unchanged is true when the page came back 304, meaning
+// the caller should reuse whatever was stored for this file last run.
+func scrapeDownloadPage(workerID int, file File, conditional bool) (result File, unchanged bool) {
+	res, err := fetch(workerID, URL+"/download/"+file.GUID, conditional)
 	if err != nil {
 		log.Println(err)
-		return file
+		return file, false
 	}
 
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return file, true
+	}
 	if res.StatusCode != http.StatusOK {
 		log.Printf("status code error: %d %s", res.StatusCode, res.Status)
-		return file
+		return file, false
 	}
 
 	doc, err := gq.NewDocumentFromReader(res.Body)
 	if err != nil {
 		log.Println(err)
-		return file
+		return file, false
 	}
 
 	link, ok := doc.Find("#localClientLink a").Attr("href")
@@ -239,44 +589,180 @@ func scrapeDownloadPage(file File) File {
 		}
 	})
 
-	return file
+	return file, false
 }
 
-func fetch(urlA string) (*http.Response, error) {
+// hostLimiters hands out one rate.Limiter per host so that, regardless of
+// how many workers are in flight, no single host ever sees more than
+// -qps requests/sec (with -burst of slack).
+var hostLimiters = struct {
+	mu sync.Mutex
+	m  map[string]*rate.Limiter
+}{m: make(map[string]*rate.Limiter)}
+
+func limiterFor(host string) *rate.Limiter {
+	hostLimiters.mu.Lock()
+	defer hostLimiters.mu.Unlock()
+
+	l, ok := hostLimiters.m[host]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(*hostQPS), *hostBurst)
+		hostLimiters.m[host] = l
+	}
+	return l
+}
+
+// fetch issues a GET for urlA. When conditional is true and -mode isn't
+// "full", it attaches If-None-Match/If-Modified-Since headers from the
+// state store so an unchanged page comes back as a cheap 304 instead of a
+// full body; scrapeSearchPageForUpperBound passes conditional=false since
+// the pagination bound must always reflect the live site. Every response is
+// recorded back to the state store so future incremental/resume runs can
+// validate against it.
+func fetch(workerID int, urlA string, conditional bool) (*http.Response, error) {
 	client := &http.Client{}
 
-	log.Printf("sleeping 3 seconds before requesting %s", urlA)
-	time.Sleep(3 * time.Second)
+	parsed, err := url.Parse(urlA)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := limiterFor(parsed.Host).Wait(context.Background()); err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequest(http.MethodGet, urlA, nil)
 	if err != nil {
-		return req.Response, err
+		return nil, err
 	}
 
-	req.Header.Set("User-Agent", UserAgent)
-	return client.Do(req)
+	req.Header.Set("User-Agent", uaProvider.ForWorker(workerID))
+	attachConditionalHeaders(req, urlA, conditional)
+
+	res, err := client.Do(req)
+	if err == nil {
+		recordPageState(urlA, res)
+	}
+	return res, err
 }
 
-func writeCSV(articles []Article, filename string) {
-	csvFile, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+func attachConditionalHeaders(req *http.Request, urlA string, conditional bool) {
+	if !conditional || *crawlMode == "full" {
+		return
+	}
+
+	page, ok, err := stateStore.GetPage(urlA)
+	if err != nil || !ok {
+		return
+	}
+
+	if *crawlMode != "resume" && time.Since(page.FetchedAt) > *since {
+		return
+	}
+
+	if page.ETag != "" {
+		req.Header.Set("If-None-Match", page.ETag)
+	}
+	if page.LastModified != "" {
+		req.Header.Set("If-Modified-Since", page.LastModified)
+	}
+}
+
+func recordPageState(urlA string, res *http.Response) {
+	err := stateStore.PutPage(urlA, state.PageState{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Status:       res.StatusCode,
+		FetchedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Printf("state: failed to record page state for %s: %v", urlA, err)
+	}
+}
+
+// csvWriter wraps one on-disk CSV file. It has no lock of its own: crawl
+// workers never write to it directly; results.csv is written only from the
+// single writeResults goroutine, and results_full.csv only from
+// writeFullResultsFromStore once the crawl has finished.
+type csvWriter struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+func newCSVWriter(filename string) (*csvWriter, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{file: f, w: csv.NewWriter(f)}, nil
+}
+
+func (c *csvWriter) writeArticle(article *Article) {
+	for _, file := range article.Files {
+		_ = c.w.Write(file.MarshalCSV())
+	}
+	c.w.Flush()
+}
+
+func (c *csvWriter) close() {
+	c.w.Flush()
+	_ = c.file.Close()
+}
+
+// writeResults drains completed articles from the results channel, appending
+// them to results.csv as they arrive. results_full.csv is rendered
+// separately, straight from the state store, once the whole crawl
+// finishes, so it stays coherent even across a partial/resumed crawl.
+func writeResults(results <-chan *Article, done chan<- struct{}) {
+	incremental, err := newCSVWriter(csvFileName)
 	if err != nil {
 		log.Println(err)
+		close(done)
 		return
 	}
+	defer incremental.close()
 
-	defer csvFile.Close()
+	for article := range results {
+		incremental.writeArticle(article)
+	}
 
-	csvWriter := csv.NewWriter(csvFile)
-	defer csvWriter.Flush()
+	close(done)
+}
+
+// writeFullResultsFromStore renders results_full.csv from every Article
+// persisted in the state store, not just the ones touched by this run, so
+// a coherent, deduplicated CSV comes out even after an incremental or
+// resumed crawl that skipped most pages.
+func writeFullResultsFromStore() {
+	full, err := newCSVWriter(csvFullResultsFileName)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer full.close()
 
-	for _, article := range articles {
-		for _, file := range article.Files {
-			_ = csvWriter.Write(file.MarshalCSV())
+	if err := full.file.Truncate(0); err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = stateStore.ForEachArticle(func(key string, data []byte) error {
+		var article Article
+		if err := json.Unmarshal(data, &article); err != nil {
+			return fmt.Errorf("article %s: %w", key, err)
 		}
+		full.writeArticle(&article)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
 	}
 }
 
 func main() {
+	flag.Parse()
+
 	logHandle, err := os.OpenFile(logFileName, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
 		log.Fatalf("error opening file: %v", err)
@@ -290,17 +776,72 @@ func main() {
 	log.Printf("WinWorld IPFS Scraper Started")
 	startTime := time.Now()
 
-	articles := make([]Article, 0)
+	uaProvider = useragent.NewProvider(useragent.Mode(*uaMode), UserAgent, uaCacheFileName)
 
-	pageUpperBound := scrapeSearchPageForUpperBound()
+	maxSize, err := ipfs.ParseSize(*ipfsMaxFileSize)
+	if err != nil {
+		log.Fatalf("invalid -ipfs-max-size: %v", err)
+	}
 
+	inspectMaxSize, err = ipfs.ParseSize(*inspectMaxFileSize)
+	if err != nil {
+		log.Fatalf("invalid -inspect-max-size: %v", err)
+	}
+
+	ipfsOpts := []ipfs.Option{
+		ipfs.WithGateways(strings.Split(*ipfsGateways, ",")),
+		ipfs.WithMaxSize(maxSize),
+	}
+	if *pin {
+		ipfsOpts = append(ipfsOpts, ipfs.WithPin(*pinAPI, *pinConcurrency))
+	}
+	ipfsVerifier = ipfs.NewVerifier(ipfsOpts...)
+
+	if *inspectArchives {
+		contentsWriter, err = newJSONLWriter(contentsFileName)
+		if err != nil {
+			log.Fatalf("error opening file: %v", err)
+		}
+		defer contentsWriter.close()
+	}
+
+	stateStore, err = state.Open(*stateDBPath)
+	if err != nil {
+		log.Fatalf("error opening state store: %v", err)
+	}
+	defer stateStore.Close()
+
+	c := newCrawler()
+
+	done := make(chan struct{})
+	go writeResults(c.results, done)
+
+	var workerWG sync.WaitGroup
+	workerWG.Add(*workers)
+	for i := 0; i < *workers; i++ {
+		go func(id int) {
+			defer workerWG.Done()
+			c.worker(id)
+		}(i)
+	}
+
+	pageUpperBound := scrapeSearchPageForUpperBound()
 	for i := uint(1); i < pageUpperBound; i++ {
-		results := scrapeSearchPage(i)
-		articles = append(articles, results...)
-		go writeCSV(results, csvFileName)
+		c.enqueue(job{kind: jobSearchPage, page: i})
 	}
 
-	writeCSV(articles, csvFullResultsFileName)
+	go func() {
+		c.jobsWG.Wait()
+		close(c.jobs)
+	}()
+
+	workerWG.Wait()
+
+	c.articlesWG.Wait()
+	close(c.results)
+	<-done
+
+	writeFullResultsFromStore()
 
 	log.Printf("WinWorld IPFS Scraper completed")
 	log.Printf("Total time: %s", time.Now().Sub(startTime).String())
@@ -0,0 +1,128 @@
+// Package state persists crawl progress to an on-disk BoltDB file so a
+// full crawl doesn't have to be discarded and restarted after an aborted
+// run: every page fetched is recorded with its conditional-request
+// metadata, and every fully-scraped Article is recorded under its WWPCLink
+// so the final CSV can be rendered straight from the store instead of an
+// in-memory slice.
+package state
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	pagesBucket    = []byte("pages")
+	articlesBucket = []byte("articles")
+)
+
+// PageState is the conditional-request metadata recorded for a fetched URL.
+type PageState struct {
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"last_modified"`
+	Status       int       `json:"status"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Store wraps a BoltDB file holding crawl state.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if necessary) the BoltDB file at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(pagesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(articlesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// GetPage returns the recorded state for url, if any.
+func (s *Store) GetPage(url string) (PageState, bool, error) {
+	var page PageState
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(pagesBucket).Get([]byte(url))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &page)
+	})
+
+	return page, found, err
+}
+
+// PutPage records the state of a just-fetched url.
+func (s *Store) PutPage(url string, page PageState) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pagesBucket).Put([]byte(url), data)
+	})
+}
+
+// PutArticle persists v (expected to be an *Article) under key, typically
+// the article's WWPCLink.
+func (s *Store) PutArticle(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(articlesBucket).Put([]byte(key), data)
+	})
+}
+
+// GetArticle decodes the article stored under key into v, returning false
+// if no article is stored under that key.
+func (s *Store) GetArticle(key string, v interface{}) (bool, error) {
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(articlesBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, v)
+	})
+
+	return found, err
+}
+
+// ForEachArticle calls fn with the raw JSON of every persisted article, so
+// a caller can render a coherent, deduplicated output from the whole store
+// without needing every article to have been touched by the current run.
+func (s *Store) ForEachArticle(fn func(key string, data []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(articlesBucket).ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
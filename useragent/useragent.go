@@ -0,0 +1,270 @@
+// Package useragent generates User-Agent strings for outgoing scraper
+// requests. In "rotate"/"sticky" modes it weights its choice of browser
+// version by real-world usage share pulled from the caniuse fulldata feed,
+// so a crawl looks like organic traffic from a mix of current browsers
+// instead of a single static bot string.
+package useragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Mode selects how ForWorker picks a User-Agent string.
+type Mode string
+
+const (
+	// ModeStatic always returns the identifier passed to NewProvider.
+	ModeStatic Mode = "static"
+	// ModeRotate picks a new weighted-random UA for every call.
+	ModeRotate Mode = "rotate"
+	// ModeSticky picks one weighted-random UA per worker and reuses it
+	// for the lifetime of the run.
+	ModeSticky Mode = "sticky"
+)
+
+const (
+	caniuseURL  = "https://raw.githubusercontent.com/Fyrd/caniuse/main/fulldata-json/data-2.0.json"
+	cacheTTL    = 24 * time.Hour
+	httpTimeout = 15 * time.Second
+)
+
+var oses = []string{
+	"Windows NT 10.0; Win64; x64",
+	"Macintosh; Intel Mac OS X 10_15_7",
+	"X11; Linux x86_64",
+	"Windows NT 10.0; WOW64",
+}
+
+// versionShare is a single browser version and its global usage percentage.
+type versionShare struct {
+	Version string  `json:"version"`
+	Share   float64 `json:"share"`
+}
+
+// browserData is the subset of the caniuse feed we care about, reduced to a
+// weighted list per browser so it can be cached cheaply on disk.
+type browserData struct {
+	Firefox   []versionShare `json:"firefox"`
+	Chromium  []versionShare `json:"chromium"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+func (b *browserData) stale() bool {
+	return b == nil || time.Since(b.FetchedAt) > cacheTTL
+}
+
+// Provider hands out User-Agent strings according to its configured Mode.
+type Provider struct {
+	mode      Mode
+	staticUA  string
+	cachePath string
+
+	dataMu sync.RWMutex
+	data   *browserData
+
+	stickyMu sync.Mutex
+	sticky   map[int]string
+}
+
+// NewProvider builds a Provider. staticUA is returned verbatim in
+// ModeStatic and used as a last-resort fallback if no browser-share data
+// can be loaded or fetched in ModeRotate/ModeSticky. cachePath is where
+// browser-share data is cached between runs (may be empty to disable
+// on-disk caching).
+func NewProvider(mode Mode, staticUA, cachePath string) *Provider {
+	p := &Provider{
+		mode:      mode,
+		staticUA:  staticUA,
+		cachePath: cachePath,
+		sticky:    make(map[int]string),
+	}
+
+	if mode == ModeStatic {
+		return p
+	}
+
+	if cached := loadCache(cachePath); cached != nil {
+		p.data = cached
+	}
+
+	if p.data.stale() {
+		if fresh, err := fetchBrowserData(); err != nil {
+			log.Printf("useragent: failed to refresh browser share data, falling back to cache/static: %v", err)
+		} else {
+			p.data = fresh
+			saveCache(cachePath, fresh)
+		}
+	}
+
+	return p
+}
+
+// ForWorker returns a User-Agent string appropriate for the given worker
+// index, according to the Provider's Mode.
+func (p *Provider) ForWorker(workerID int) string {
+	switch p.mode {
+	case ModeRotate:
+		return p.weightedRandom()
+	case ModeSticky:
+		p.stickyMu.Lock()
+		defer p.stickyMu.Unlock()
+		if ua, ok := p.sticky[workerID]; ok {
+			return ua
+		}
+		ua := p.weightedRandom()
+		p.sticky[workerID] = ua
+		return ua
+	default:
+		return p.staticUA
+	}
+}
+
+func (p *Provider) weightedRandom() string {
+	p.dataMu.RLock()
+	data := p.data
+	p.dataMu.RUnlock()
+
+	if data == nil || (len(data.Firefox) == 0 && len(data.Chromium) == 0) {
+		return p.staticUA
+	}
+
+	type candidate struct {
+		browser string
+		versionShare
+	}
+
+	candidates := make([]candidate, 0, len(data.Firefox)+len(data.Chromium))
+	for _, v := range data.Firefox {
+		candidates = append(candidates, candidate{"firefox", v})
+	}
+	for _, v := range data.Chromium {
+		candidates = append(candidates, candidate{"chromium", v})
+	}
+
+	var total float64
+	for _, c := range candidates {
+		total += c.Share
+	}
+	if total <= 0 {
+		return p.staticUA
+	}
+
+	pick := rand.Float64() * total
+	var chosen candidate
+	for _, c := range candidates {
+		pick -= c.Share
+		chosen = c
+		if pick <= 0 {
+			break
+		}
+	}
+
+	osName := oses[rand.Intn(len(oses))]
+	return formatUA(chosen.browser, chosen.Version, osName)
+}
+
+func formatUA(browser, version, osName string) string {
+	switch browser {
+	case "firefox":
+		return fmt.Sprintf("Mozilla/5.0 (%s; rv:%s) Gecko/20100101 Firefox/%s", osName, version, version)
+	default: // chromium
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s Safari/537.36", osName, version)
+	}
+}
+
+// fetchBrowserData pulls the current caniuse fulldata feed and reduces it to
+// a weighted Firefox/Chromium version list.
+func fetchBrowserData() (*browserData, error) {
+	client := &http.Client{Timeout: httpTimeout}
+
+	res, err := client.Get(caniuseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Agents map[string]struct {
+			UsageGlobal map[string]float64 `json:"usage_global"`
+		} `json:"agents"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	data := &browserData{FetchedAt: time.Now()}
+
+	if ff, ok := raw.Agents["firefox"]; ok {
+		data.Firefox = toVersionShares(ff.UsageGlobal)
+	}
+	if cr, ok := raw.Agents["chrome"]; ok {
+		data.Chromium = toVersionShares(cr.UsageGlobal)
+	}
+
+	return data, nil
+}
+
+func toVersionShares(usage map[string]float64) []versionShare {
+	out := make([]versionShare, 0, len(usage))
+	for version, share := range usage {
+		if share <= 0 {
+			continue
+		}
+		out = append(out, versionShare{Version: version, Share: share})
+	}
+	return out
+}
+
+func loadCache(path string) *browserData {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var data browserData
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		log.Printf("useragent: failed to parse cache %s: %v", path, err)
+		return nil
+	}
+
+	return &data
+}
+
+func saveCache(path string, data *browserData) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Printf("useragent: failed to write cache %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(data); err != nil {
+		log.Printf("useragent: failed to encode cache %s: %v", path, err)
+	}
+}